@@ -47,18 +47,33 @@ var (
 	_ AuthMethod = (*gitHubAuthMethod)(nil)
 	_ AuthMethod = (*userPassAuthMethod)(nil)
 	_ AuthMethod = (*kubernetesAuthMethod)(nil)
+	_ AuthMethod = (*awsAuthMethod)(nil)
+	_ AuthMethod = (*jwtAuthMethod)(nil)
+	_ AuthMethod = (*renewingAuthMethod)(nil)
+	_ AuthMethod = erroringAuthMethod{}
 )
 
-// EnvAuthMethod chooses the first auth method to have the correct environment
-// variables set, in this order of precedence:
+// EnvAuthMethod chooses an auth method based on environment variables.
+//
+// If $VAULT_AUTH_METHOD names a method registered with RegisterAuthMethod,
+// that method is used directly, constructed from its own environment
+// variables (with $VAULT_AUTH_MOUNT_PATH, if set, used as its mount).
+//
+// Otherwise, it falls back to the first of the following methods to have
+// its correct environment variables set, in this order of precedence:
 //
 //		AppRoleAuthMethod
 //		GitHubAuthMethod
 //		UserPassAuthMethod
 //		TokenAuthMethod
 //	 	KubernetesAuthMethod
+//		AWSAuthMethod
 //		AppIDAuthMethod	// Deprecated
 func EnvAuthMethod() AuthMethod {
+	if auth := chosenEnvAuthMethod(os.DirFS("/")); auth != nil {
+		return auth
+	}
+
 	return &envAuthMethod{
 		// sorted in order of precedence
 		methods: []AuthMethod{
@@ -67,6 +82,7 @@ func EnvAuthMethod() AuthMethod {
 			UserPassAuthMethod("", "", ""),
 			TokenAuthMethod(""),
 			KubernetesAuthMethod("", "", ""),
+			AWSAuthMethod("", "", "", ""),
 			AppIDAuthMethod("", "", ""),
 		},
 	}
@@ -78,14 +94,24 @@ type envAuthMethod struct {
 }
 
 func (m *envAuthMethod) Login(ctx context.Context, client *api.Client) (err error) {
-	if m.chosen == nil {
-		for _, auth := range m.methods {
-			err = auth.Login(ctx, client)
-			if err == nil {
-				m.chosen = auth
+	// if we've already chosen a method (e.g. on a prior Login), re-use it
+	// directly rather than re-running the whole precedence scan, so that
+	// re-login (as used by the token renewer) re-authenticates with the
+	// same method instead of silently no-op'ing.
+	if m.chosen != nil {
+		if err = m.chosen.Login(ctx, client); err == nil {
+			return nil
+		}
+
+		m.chosen = nil
+	}
+
+	for _, auth := range m.methods {
+		err = auth.Login(ctx, client)
+		if err == nil {
+			m.chosen = auth
 
-				break
-			}
+			break
 		}
 	}
 
@@ -159,7 +185,12 @@ func (m *tokenAuthMethod) Logout(ctx context.Context, client *api.Client) error
 
 // AppRoleAuthMethod authenticates to Vault with the AppRole auth method. If
 // either roleID or secretID are omitted, the values will be read from the
-// $VAULT_ROLE_ID and/or $VAULT_SECRET_ID environment variables.
+// $VAULT_ROLE_ID and/or $VAULT_SECRET_ID environment variables. The
+// secretID may also be delivered as a file at $VAULT_SECRET_ID_FILE, as the
+// name of another environment variable given by $VAULT_SECRET_ID_ENV, or -
+// only if secretID is otherwise unset - as a Vault response-wrapping token
+// named by $VAULT_SECRET_ID_WRAPPING_TOKEN (unwrapped the same way as
+// AppRoleAuthMethodWithWrappedSecretID).
 //
 // If mount is not set, it defaults to the value of $VAULT_AUTH_APPROLE_MOUNT
 // or "approle".
@@ -174,9 +205,31 @@ func AppRoleAuthMethod(roleID, secretID, mount string) AuthMethod {
 	}
 }
 
+// AppRoleAuthMethodWithWrappedSecretID authenticates to Vault with the
+// AppRole auth method, where the secretID is delivered as a Vault
+// response-wrapping token rather than a raw value. On Login, the wrapping
+// token is unwrapped via client.Logical().UnwrapWithContext to retrieve the
+// real secret_id before authenticating, which allows an orchestrator to
+// deliver a short-lived, single-use SecretID safely.
+//
+// If mount is not set, it defaults to the value of $VAULT_AUTH_APPROLE_MOUNT
+// or "approle".
+//
+// See also https://www.vaultproject.io/docs/auth/approle and
+// https://www.vaultproject.io/docs/concepts/response-wrapping
+func AppRoleAuthMethodWithWrappedSecretID(roleID, wrappingToken, mount string) AuthMethod {
+	return &appRoleAuthMethod{
+		fsys:          os.DirFS("/"),
+		roleID:        roleID,
+		wrappingToken: wrappingToken,
+		mount:         mount,
+	}
+}
+
 type appRoleAuthMethod struct {
 	fsys             fs.FS
 	roleID, secretID string
+	wrappingToken    string
 	mount            string
 }
 
@@ -186,7 +239,11 @@ func (m *appRoleAuthMethod) Login(ctx context.Context, client *api.Client) error
 		return fmt.Errorf("approle auth failure: no role_id provided")
 	}
 
-	secretID := findValue(m.secretID, "VAULT_SECRET_ID", "", m.fsys)
+	secretID, err := m.resolveSecretID(ctx, client)
+	if err != nil {
+		return fmt.Errorf("approle auth failure: %w", err)
+	}
+
 	if secretID == "" {
 		return fmt.Errorf("approle auth failure: no secret_id provided")
 	}
@@ -208,6 +265,64 @@ func (m *appRoleAuthMethod) Logout(ctx context.Context, client *api.Client) erro
 	return revokeToken(ctx, client)
 }
 
+// resolveSecretID finds the secret_id to authenticate with, in order: the
+// explicit wrappingToken to unwrap, the literal secretID, $VAULT_SECRET_ID,
+// the file at $VAULT_SECRET_ID_FILE, the environment variable named by
+// $VAULT_SECRET_ID_ENV, or - only when none of those were set, including the
+// env vars - a wrapping token from $VAULT_SECRET_ID_WRAPPING_TOKEN. Checking
+// $VAULT_SECRET_ID_WRAPPING_TOKEN last means it's truly a last resort, and
+// can't silently override any of the other sources.
+func (m *appRoleAuthMethod) resolveSecretID(ctx context.Context, client *api.Client) (string, error) {
+	if m.wrappingToken != "" {
+		return m.unwrapSecretID(ctx, client, m.wrappingToken)
+	}
+
+	if m.secretID != "" {
+		return m.secretID, nil
+	}
+
+	if secretID := env.GetenvFS(m.fsys, "VAULT_SECRET_ID"); secretID != "" {
+		return secretID, nil
+	}
+
+	if p := env.GetenvFS(m.fsys, "VAULT_SECRET_ID_FILE"); p != "" {
+		p = strings.TrimPrefix(p, "/")
+
+		b, err := fs.ReadFile(m.fsys, p)
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret_id file from %q: %w", p, err)
+		}
+
+		return strings.TrimSpace(string(b)), nil
+	}
+
+	if name := env.GetenvFS(m.fsys, "VAULT_SECRET_ID_ENV"); name != "" {
+		return env.GetenvFS(m.fsys, name), nil
+	}
+
+	if wrappingToken := env.GetenvFS(m.fsys, "VAULT_SECRET_ID_WRAPPING_TOKEN"); wrappingToken != "" {
+		return m.unwrapSecretID(ctx, client, wrappingToken)
+	}
+
+	return "", nil
+}
+
+// unwrapSecretID unwraps a Vault response-wrapping token to retrieve the
+// real secret_id it carries.
+func (m *appRoleAuthMethod) unwrapSecretID(ctx context.Context, client *api.Client, wrappingToken string) (string, error) {
+	wrapped, err := client.Logical().UnwrapWithContext(ctx, wrappingToken)
+	if err != nil {
+		return "", fmt.Errorf("failed to unwrap secret_id: %w", err)
+	}
+
+	secretID, ok := wrapped.Data["secret_id"].(string)
+	if !ok || secretID == "" {
+		return "", fmt.Errorf("wrapped response did not contain a secret_id")
+	}
+
+	return secretID, nil
+}
+
 // AppIDAuthMethod authenticates to Vault with the AppID auth method.
 //
 // Deprecated: transition to AppRole instead - see https://www.vaultproject.io/docs/auth/app-id
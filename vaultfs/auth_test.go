@@ -0,0 +1,143 @@
+package vaultfs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"testing/fstest"
+)
+
+// unwrapHandler fakes Vault's sys/wrapping/unwrap response, returning
+// secretID for any wrapping token.
+func unwrapHandler(secretID string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"secret_id": secretID,
+			},
+		})
+	}
+}
+
+func TestAppRoleAuthMethod_resolveSecretID(t *testing.T) {
+	fsys := fstest.MapFS{
+		"var/run/secret-id": {Data: []byte("from-file\n")},
+	}
+
+	t.Run("explicit wrapping token takes precedence", func(t *testing.T) {
+		client := testVaultClient(t, unwrapHandler("from-wrapping-token"))
+
+		t.Setenv("VAULT_SECRET_ID", "from-env")
+		t.Setenv("VAULT_SECRET_ID_WRAPPING_TOKEN", "fallback-token")
+
+		m := &appRoleAuthMethod{fsys: fsys, secretID: "from-literal", wrappingToken: "explicit-token"}
+
+		secretID, err := m.resolveSecretID(context.Background(), client)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if secretID != "from-wrapping-token" {
+			t.Errorf("expected %q, got %q", "from-wrapping-token", secretID)
+		}
+	})
+
+	t.Run("explicit secretID takes precedence over env and file", func(t *testing.T) {
+		t.Setenv("VAULT_SECRET_ID", "from-env")
+		t.Setenv("VAULT_SECRET_ID_FILE", "/var/run/secret-id")
+
+		m := &appRoleAuthMethod{fsys: fsys, secretID: "from-literal"}
+
+		secretID, err := m.resolveSecretID(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if secretID != "from-literal" {
+			t.Errorf("expected %q, got %q", "from-literal", secretID)
+		}
+	})
+
+	t.Run("env var takes precedence over file", func(t *testing.T) {
+		t.Setenv("VAULT_SECRET_ID", "from-env")
+		t.Setenv("VAULT_SECRET_ID_FILE", "/var/run/secret-id")
+
+		m := &appRoleAuthMethod{fsys: fsys}
+
+		secretID, err := m.resolveSecretID(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if secretID != "from-env" {
+			t.Errorf("expected %q, got %q", "from-env", secretID)
+		}
+	})
+
+	t.Run("file takes precedence over named env var", func(t *testing.T) {
+		t.Setenv("VAULT_SECRET_ID_FILE", "/var/run/secret-id")
+		t.Setenv("VAULT_SECRET_ID_ENV", "OTHER_VAR")
+		t.Setenv("OTHER_VAR", "from-named-env")
+
+		m := &appRoleAuthMethod{fsys: fsys}
+
+		secretID, err := m.resolveSecretID(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if secretID != "from-file" {
+			t.Errorf("expected %q, got %q", "from-file", secretID)
+		}
+	})
+
+	t.Run("named env var takes precedence over fallback wrapping token env var", func(t *testing.T) {
+		client := testVaultClient(t, unwrapHandler("from-fallback-wrapping-token"))
+
+		t.Setenv("VAULT_SECRET_ID_ENV", "OTHER_VAR")
+		t.Setenv("OTHER_VAR", "from-named-env")
+		t.Setenv("VAULT_SECRET_ID_WRAPPING_TOKEN", "fallback-token")
+
+		m := &appRoleAuthMethod{fsys: fstest.MapFS{}}
+
+		secretID, err := m.resolveSecretID(context.Background(), client)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if secretID != "from-named-env" {
+			t.Errorf("expected %q, got %q", "from-named-env", secretID)
+		}
+	})
+
+	t.Run("falls back to wrapping token env var when nothing else is set", func(t *testing.T) {
+		client := testVaultClient(t, unwrapHandler("from-fallback-wrapping-token"))
+
+		t.Setenv("VAULT_SECRET_ID_WRAPPING_TOKEN", "fallback-token")
+
+		m := &appRoleAuthMethod{fsys: fstest.MapFS{}}
+
+		secretID, err := m.resolveSecretID(context.Background(), client)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if secretID != "from-fallback-wrapping-token" {
+			t.Errorf("expected %q, got %q", "from-fallback-wrapping-token", secretID)
+		}
+	})
+
+	t.Run("nothing set returns empty string", func(t *testing.T) {
+		m := &appRoleAuthMethod{fsys: fstest.MapFS{}}
+
+		secretID, err := m.resolveSecretID(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if secretID != "" {
+			t.Errorf("expected empty string, got %q", secretID)
+		}
+	})
+}
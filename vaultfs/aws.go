@@ -0,0 +1,192 @@
+package vaultfs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
+	"github.com/hashicorp/vault/api"
+)
+
+const (
+	awsAuthTypeIAM = "iam"
+	awsAuthTypeEC2 = "ec2"
+
+	stsGetCallerIdentityBody = "Action=GetCallerIdentity&Version=2011-06-15"
+)
+
+// AWSAuthMethod authenticates to Vault with the AWS auth method, using
+// either the "iam" or "ec2" authType.
+//
+// For the "iam" type, credentials are resolved from the default AWS
+// credential chain (environment variables, shared config/credentials
+// files, EC2/ECS instance metadata, or IRSA web identity), and are used to
+// sign a GetCallerIdentity STS request that Vault verifies on Vault's side.
+//
+// For the "ec2" type, the PKCS7-signed instance identity document is
+// fetched from the EC2 instance metadata service instead. Vault returns a
+// nonce on the first ec2 login, which is then remembered and resent on
+// subsequent logins (as Vault requires, unless the role disables
+// reauthentication).
+//
+// If role or authType are omitted, their values will be read from the
+// $VAULT_AUTH_AWS_ROLE and/or $VAULT_AUTH_AWS_TYPE environment variables,
+// with authType defaulting to "iam". Login fails fast, without touching the
+// AWS credential chain or IMDS, if no role is available from either source.
+// If region is omitted, it's read from
+// $AWS_REGION or $AWS_DEFAULT_REGION.
+//
+// If mount is not set, it defaults to the value of $VAULT_AUTH_AWS_MOUNT or
+// "aws".
+//
+// See also https://www.vaultproject.io/docs/auth/aws
+func AWSAuthMethod(role, authType, region, mount string) AuthMethod {
+	return &awsAuthMethod{
+		fsys:     os.DirFS("/"),
+		role:     role,
+		authType: authType,
+		region:   region,
+		mount:    mount,
+	}
+}
+
+type awsAuthMethod struct {
+	fsys                   fs.FS
+	role, authType, region string
+	mount                  string
+
+	// nonce is the server-issued nonce from the first ec2 login, resent on
+	// subsequent logins as Vault requires.
+	nonce string
+}
+
+func (m *awsAuthMethod) Login(ctx context.Context, client *api.Client) error {
+	role := findValue(m.role, "VAULT_AUTH_AWS_ROLE", "", m.fsys)
+	if role == "" {
+		return fmt.Errorf("aws auth failure: no role provided")
+	}
+
+	mount := findValue(m.mount, "VAULT_AUTH_AWS_MOUNT", "aws", m.fsys)
+	authType := findValue(m.authType, "VAULT_AUTH_AWS_TYPE", awsAuthTypeIAM, m.fsys)
+
+	var (
+		vars map[string]interface{}
+		err  error
+	)
+
+	switch authType {
+	case awsAuthTypeIAM:
+		vars, err = m.iamLoginVars(ctx, role)
+	case awsAuthTypeEC2:
+		vars, err = m.ec2LoginVars(ctx, role)
+	default:
+		return fmt.Errorf("aws auth failure: unknown auth type %q", authType)
+	}
+
+	if err != nil {
+		return fmt.Errorf("aws auth failure: %w", err)
+	}
+
+	secret, err := remoteAuth(ctx, client, mount, "", vars)
+	if err != nil {
+		return fmt.Errorf("aws login failed: %w", err)
+	}
+
+	if authType == awsAuthTypeEC2 && secret.Auth != nil {
+		m.nonce = secret.Auth.Metadata["nonce"]
+	}
+
+	client.SetToken(secret.Auth.ClientToken)
+
+	return nil
+}
+
+func (m *awsAuthMethod) Logout(ctx context.Context, client *api.Client) error {
+	return revokeToken(ctx, client)
+}
+
+// iamLoginVars signs a GetCallerIdentity STS request with credentials from
+// the default AWS credential chain, and returns the vars Vault's aws/login
+// endpoint expects for the iam auth type.
+func (m *awsAuthMethod) iamLoginVars(ctx context.Context, role string) (map[string]interface{}, error) {
+	region := findValue(m.region, "AWS_REGION", "", m.fsys)
+	region = findValue(region, "AWS_DEFAULT_REGION", "us-east-1", m.fsys)
+
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(region))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	creds, err := cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+
+	stsURL := fmt.Sprintf("https://sts.%s.amazonaws.com/", cfg.Region)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, stsURL, strings.NewReader(stsGetCallerIdentityBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build sts request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+
+	if role != "" {
+		req.Header.Set("X-Vault-AWS-IAM-Server-ID", role)
+	}
+
+	bodyHash := sha256.Sum256([]byte(stsGetCallerIdentityBody))
+
+	signer := v4.NewSigner()
+	if err := signer.SignHTTP(ctx, creds, req, fmt.Sprintf("%x", bodyHash), "sts", cfg.Region, time.Now()); err != nil {
+		return nil, fmt.Errorf("failed to sign sts request: %w", err)
+	}
+
+	headers, err := json.Marshal(req.Header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal sts request headers: %w", err)
+	}
+
+	return map[string]interface{}{
+		"role":                    role,
+		"iam_http_request_method": http.MethodPost,
+		"iam_request_url":         base64.StdEncoding.EncodeToString([]byte(stsURL)),
+		"iam_request_body":        base64.StdEncoding.EncodeToString([]byte(stsGetCallerIdentityBody)),
+		"iam_request_headers":     base64.StdEncoding.EncodeToString(headers),
+	}, nil
+}
+
+// ec2LoginVars fetches the PKCS7-signed instance identity document from
+// IMDS, and returns the vars Vault's aws/login endpoint expects for the ec2
+// auth type.
+func (m *awsAuthMethod) ec2LoginVars(ctx context.Context, role string) (map[string]interface{}, error) {
+	imdsClient := imds.New(imds.Options{})
+
+	out, err := imdsClient.GetDynamicData(ctx, &imds.GetDynamicDataInput{Path: "instance-identity/pkcs7"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch instance identity document: %w", err)
+	}
+	defer out.Content.Close()
+
+	pkcs7, err := io.ReadAll(out.Content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read instance identity document: %w", err)
+	}
+
+	return map[string]interface{}{
+		"role":  role,
+		"pkcs7": strings.ReplaceAll(string(pkcs7), "\n", ""),
+		"nonce": m.nonce,
+	}, nil
+}
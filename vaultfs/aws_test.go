@@ -0,0 +1,105 @@
+package vaultfs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestAWSAuthMethod_Login_NoRole(t *testing.T) {
+	m := &awsAuthMethod{fsys: fstest.MapFS{}}
+
+	err := m.Login(context.Background(), nil)
+	if err == nil {
+		t.Fatal("expected an error when no role is configured")
+	}
+
+	if !strings.Contains(err.Error(), "no role provided") {
+		t.Errorf("expected error about a missing role, got: %v", err)
+	}
+}
+
+func TestAWSAuthMethod_Login_UnknownAuthType(t *testing.T) {
+	m := &awsAuthMethod{fsys: fstest.MapFS{}, role: "my-role", authType: "bogus"}
+
+	err := m.Login(context.Background(), nil)
+	if err == nil {
+		t.Fatal("expected an error for an unknown auth type")
+	}
+
+	if !strings.Contains(err.Error(), `unknown auth type "bogus"`) {
+		t.Errorf("expected error about an unknown auth type, got: %v", err)
+	}
+}
+
+// ec2MetadataHandler fakes the two IMDSv2 requests ec2LoginVars makes: the
+// token PUT, and the GET for the pkcs7 instance identity document.
+func ec2MetadataHandler(pkcs7 string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && strings.Contains(r.URL.Path, "/api/token"):
+			_, _ = w.Write([]byte("fake-imds-token"))
+		case r.Method == http.MethodGet && strings.Contains(r.URL.Path, "instance-identity/pkcs7"):
+			_, _ = w.Write([]byte(pkcs7))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+}
+
+// TestAWSAuthMethod_Login_EC2NoncePersistence verifies that the nonce Vault
+// returns from the first ec2 login is resent on the next one, as Vault
+// requires for the ec2 auth type.
+func TestAWSAuthMethod_Login_EC2NoncePersistence(t *testing.T) {
+	imdsSrv := httptest.NewServer(ec2MetadataHandler("fake-pkcs7-document"))
+	t.Cleanup(imdsSrv.Close)
+
+	t.Setenv("AWS_EC2_METADATA_SERVICE_ENDPOINT", imdsSrv.URL)
+
+	var nonces []string
+
+	client := testVaultClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+
+		nonces = append(nonces, fmt.Sprintf("%v", body["nonce"]))
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"auth": map[string]interface{}{
+				"client_token": "test-token",
+				"metadata":     map[string]interface{}{"nonce": "server-nonce"},
+			},
+		})
+	})
+
+	m := &awsAuthMethod{fsys: fstest.MapFS{}, role: "my-role", authType: awsAuthTypeEC2}
+
+	if err := m.Login(context.Background(), client); err != nil {
+		t.Fatalf("first login failed: %v", err)
+	}
+
+	if m.nonce != "server-nonce" {
+		t.Fatalf("expected nonce to be recorded from the first login, got %q", m.nonce)
+	}
+
+	if err := m.Login(context.Background(), client); err != nil {
+		t.Fatalf("second login failed: %v", err)
+	}
+
+	if len(nonces) != 2 {
+		t.Fatalf("expected 2 login requests, got %d", len(nonces))
+	}
+
+	if nonces[0] != "" && nonces[0] != "<nil>" {
+		t.Errorf("expected no nonce on the first login, got %q", nonces[0])
+	}
+
+	if nonces[1] != "server-nonce" {
+		t.Errorf("expected the second login to resend the server-issued nonce, got %q", nonces[1])
+	}
+}
@@ -0,0 +1,104 @@
+package vaultfs
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/hairyhenderson/go-fsimpl/internal/env"
+	"github.com/hashicorp/vault/api"
+)
+
+// JWTAuthMethod authenticates to Vault with the JWT/OIDC auth method.
+//
+// The jwt is resolved in this order: the literal jwt argument, the
+// $VAULT_AUTH_JWT environment variable, a file path read from
+// $VAULT_AUTH_JWT_PATH, or the projected service account token at
+// $BOUND_SA_TOKEN_PATH (useful for GKE/EKS workload identity federation and
+// Kubernetes projected service account tokens).
+//
+// If role is omitted, its value will be read from the $VAULT_AUTH_ROLE
+// environment variable.
+//
+// If mount is not set, it defaults to the value of $VAULT_AUTH_JWT_MOUNT or
+// "jwt".
+//
+// See also https://www.vaultproject.io/docs/auth/jwt
+func JWTAuthMethod(role, jwt, mount string) AuthMethod {
+	return &jwtAuthMethod{
+		fsys:  os.DirFS("/"),
+		role:  role,
+		jwt:   jwt,
+		mount: mount,
+	}
+}
+
+type jwtAuthMethod struct {
+	fsys      fs.FS
+	role, jwt string
+	mount     string
+}
+
+func (m *jwtAuthMethod) Login(ctx context.Context, client *api.Client) error {
+	role := findValue(m.role, "VAULT_AUTH_ROLE", "", m.fsys)
+	if role == "" {
+		return fmt.Errorf("jwt auth failure: no role provided")
+	}
+
+	jwt, err := m.findJWT()
+	if err != nil {
+		return fmt.Errorf("jwt auth failure: %w", err)
+	}
+
+	if jwt == "" {
+		return fmt.Errorf("jwt auth failure: no jwt provided")
+	}
+
+	mount := findValue(m.mount, "VAULT_AUTH_JWT_MOUNT", "jwt", m.fsys)
+
+	secret, err := remoteAuth(ctx, client, mount, "",
+		map[string]interface{}{"role": role, "jwt": jwt})
+	if err != nil {
+		return fmt.Errorf("jwt login failed: %w", err)
+	}
+
+	client.SetToken(secret.Auth.ClientToken)
+
+	return nil
+}
+
+func (m *jwtAuthMethod) Logout(ctx context.Context, client *api.Client) error {
+	return revokeToken(ctx, client)
+}
+
+// findJWT resolves the JWT in order: the literal value, $VAULT_AUTH_JWT, the
+// file at $VAULT_AUTH_JWT_PATH, or the file at $BOUND_SA_TOKEN_PATH.
+func (m *jwtAuthMethod) findJWT() (string, error) {
+	if m.jwt != "" {
+		return m.jwt, nil
+	}
+
+	if jwt := env.GetenvFS(m.fsys, "VAULT_AUTH_JWT"); jwt != "" {
+		return jwt, nil
+	}
+
+	for _, pathVar := range []string{"VAULT_AUTH_JWT_PATH", "BOUND_SA_TOKEN_PATH"} {
+		p := env.GetenvFS(m.fsys, pathVar)
+		if p == "" {
+			continue
+		}
+
+		p = strings.TrimPrefix(p, "/")
+
+		b, err := fs.ReadFile(m.fsys, p)
+		if err != nil {
+			return "", fmt.Errorf("failed to read jwt file from %q: %w", p, err)
+		}
+
+		return strings.TrimSpace(string(b)), nil
+	}
+
+	return "", nil
+}
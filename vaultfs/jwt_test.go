@@ -0,0 +1,101 @@
+package vaultfs
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestJWTAuthMethod_findJWT(t *testing.T) {
+	fsys := fstest.MapFS{
+		"var/run/jwt":    {Data: []byte("from-file\n")},
+		"var/run/sa-tok": {Data: []byte("from-sa-token\n")},
+	}
+
+	t.Run("literal value takes precedence", func(t *testing.T) {
+		t.Setenv("VAULT_AUTH_JWT", "from-env")
+		t.Setenv("VAULT_AUTH_JWT_PATH", "/var/run/jwt")
+		t.Setenv("BOUND_SA_TOKEN_PATH", "/var/run/sa-tok")
+
+		m := &jwtAuthMethod{fsys: fsys, jwt: "from-literal"}
+
+		jwt, err := m.findJWT()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if jwt != "from-literal" {
+			t.Errorf("expected %q, got %q", "from-literal", jwt)
+		}
+	})
+
+	t.Run("env var takes precedence over files", func(t *testing.T) {
+		t.Setenv("VAULT_AUTH_JWT", "from-env")
+		t.Setenv("VAULT_AUTH_JWT_PATH", "/var/run/jwt")
+		t.Setenv("BOUND_SA_TOKEN_PATH", "/var/run/sa-tok")
+
+		m := &jwtAuthMethod{fsys: fsys}
+
+		jwt, err := m.findJWT()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if jwt != "from-env" {
+			t.Errorf("expected %q, got %q", "from-env", jwt)
+		}
+	})
+
+	t.Run("jwt path takes precedence over bound sa token path", func(t *testing.T) {
+		t.Setenv("VAULT_AUTH_JWT_PATH", "/var/run/jwt")
+		t.Setenv("BOUND_SA_TOKEN_PATH", "/var/run/sa-tok")
+
+		m := &jwtAuthMethod{fsys: fsys}
+
+		jwt, err := m.findJWT()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if jwt != "from-file" {
+			t.Errorf("expected %q, got %q", "from-file", jwt)
+		}
+	})
+
+	t.Run("falls back to bound sa token path", func(t *testing.T) {
+		t.Setenv("BOUND_SA_TOKEN_PATH", "/var/run/sa-tok")
+
+		m := &jwtAuthMethod{fsys: fsys}
+
+		jwt, err := m.findJWT()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if jwt != "from-sa-token" {
+			t.Errorf("expected %q, got %q", "from-sa-token", jwt)
+		}
+	})
+
+	t.Run("no source set returns empty string", func(t *testing.T) {
+		m := &jwtAuthMethod{fsys: fstest.MapFS{}}
+
+		jwt, err := m.findJWT()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if jwt != "" {
+			t.Errorf("expected empty string, got %q", jwt)
+		}
+	})
+
+	t.Run("unreadable file returns an error", func(t *testing.T) {
+		t.Setenv("VAULT_AUTH_JWT_PATH", "/var/run/missing")
+
+		m := &jwtAuthMethod{fsys: fstest.MapFS{}}
+
+		if _, err := m.findJWT(); err == nil {
+			t.Fatal("expected an error, got nil")
+		}
+	})
+}
@@ -0,0 +1,156 @@
+package vaultfs
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"sync"
+
+	"github.com/hairyhenderson/go-fsimpl/internal/env"
+	"github.com/hashicorp/vault/api"
+)
+
+// AuthMethodFactory builds an AuthMethod from the query parameters of a spec
+// parsed by ParseAuthMethod.
+type AuthMethodFactory func(params map[string]string) (AuthMethod, error)
+
+var (
+	authMethodsMu sync.RWMutex
+	authMethods   = map[string]AuthMethodFactory{}
+)
+
+// RegisterAuthMethod registers a named AuthMethodFactory, making it
+// available to ParseAuthMethod and to selection via $VAULT_AUTH_METHOD.
+// This lets third parties plug in new AuthMethod implementations (TLS
+// certificates, LDAP, Okta, etc.) without patching vaultfs.
+//
+// Registering under a name that's already registered replaces it.
+//
+// Note: this registry currently only feeds EnvAuthMethod's selection and
+// ParseAuthMethod's URL spec parsing. Wiring it into a URL-based fs
+// constructor (e.g. a "vault_auth_method" query param on a vault:// URL)
+// is left for when that constructor exists in this package - there's no
+// fs/URL constructor here yet to hang it off of.
+func RegisterAuthMethod(name string, factory AuthMethodFactory) {
+	authMethodsMu.Lock()
+	defer authMethodsMu.Unlock()
+
+	authMethods[name] = factory
+}
+
+// ParseAuthMethod builds an AuthMethod from a spec formatted as a URL, e.g.
+// "approle://?role_id=...&mount=approle" or "kubernetes://?role=my-role".
+// The URL scheme selects the registered auth method by name, and its query
+// parameters are passed to that method's factory.
+func ParseAuthMethod(spec string) (AuthMethod, error) {
+	u, err := url.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth method spec %q: %w", spec, err)
+	}
+
+	authMethodsMu.RLock()
+	factory, ok := authMethods[u.Scheme]
+	authMethodsMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("no auth method registered for %q", u.Scheme)
+	}
+
+	params := map[string]string{}
+	for k, v := range u.Query() {
+		if len(v) > 0 {
+			params[k] = v[0]
+		}
+	}
+
+	auth, err := factory(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build %q auth method: %w", u.Scheme, err)
+	}
+
+	return auth, nil
+}
+
+// chosenEnvAuthMethod returns the AuthMethod registered under
+// $VAULT_AUTH_METHOD, or nil if that variable isn't set. $VAULT_AUTH_MOUNT_PATH,
+// if set, is passed through as the method's mount param.
+//
+// If $VAULT_AUTH_METHOD is set but names an unregistered method, or its
+// factory errors, the returned AuthMethod fails with a descriptive error on
+// Login rather than silently falling back to EnvAuthMethod's precedence
+// scan - an explicit, misconfigured selection shouldn't be masked by an
+// unrelated method succeeding.
+func chosenEnvAuthMethod(fsys fs.FS) AuthMethod {
+	name := env.GetenvFS(fsys, "VAULT_AUTH_METHOD")
+	if name == "" {
+		return nil
+	}
+
+	authMethodsMu.RLock()
+	factory, ok := authMethods[name]
+	authMethodsMu.RUnlock()
+
+	if !ok {
+		return erroringAuthMethod{fmt.Errorf("no auth method registered for $VAULT_AUTH_METHOD=%q", name)}
+	}
+
+	params := map[string]string{}
+	if mount := env.GetenvFS(fsys, "VAULT_AUTH_MOUNT_PATH"); mount != "" {
+		params["mount"] = mount
+	}
+
+	auth, err := factory(params)
+	if err != nil {
+		return erroringAuthMethod{fmt.Errorf("failed to build %q auth method from $VAULT_AUTH_METHOD: %w", name, err)}
+	}
+
+	return auth
+}
+
+// erroringAuthMethod is an AuthMethod that always fails Login with err. It's
+// used to surface a misconfigured $VAULT_AUTH_METHOD selection instead of
+// silently discarding it.
+type erroringAuthMethod struct{ err error }
+
+func (m erroringAuthMethod) Login(ctx context.Context, client *api.Client) error {
+	return m.err
+}
+
+func (m erroringAuthMethod) Logout(ctx context.Context, client *api.Client) error {
+	return nil
+}
+
+func init() {
+	RegisterAuthMethod("token", func(p map[string]string) (AuthMethod, error) {
+		return TokenAuthMethod(p["token"]), nil
+	})
+
+	RegisterAuthMethod("approle", func(p map[string]string) (AuthMethod, error) {
+		if wrappingToken := p["wrapping_token"]; wrappingToken != "" {
+			return AppRoleAuthMethodWithWrappedSecretID(p["role_id"], wrappingToken, p["mount"]), nil
+		}
+
+		return AppRoleAuthMethod(p["role_id"], p["secret_id"], p["mount"]), nil
+	})
+
+	RegisterAuthMethod("github", func(p map[string]string) (AuthMethod, error) {
+		return GitHubAuthMethod(p["token"], p["mount"]), nil
+	})
+
+	RegisterAuthMethod("userpass", func(p map[string]string) (AuthMethod, error) {
+		return UserPassAuthMethod(p["username"], p["password"], p["mount"]), nil
+	})
+
+	RegisterAuthMethod("kubernetes", func(p map[string]string) (AuthMethod, error) {
+		return KubernetesAuthMethod(p["role"], p["sa_token_path"], p["mount"]), nil
+	})
+
+	RegisterAuthMethod("aws", func(p map[string]string) (AuthMethod, error) {
+		return AWSAuthMethod(p["role"], p["type"], p["region"], p["mount"]), nil
+	})
+
+	RegisterAuthMethod("jwt", func(p map[string]string) (AuthMethod, error) {
+		return JWTAuthMethod(p["role"], p["jwt"], p["mount"]), nil
+	})
+}
@@ -0,0 +1,92 @@
+package vaultfs
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+)
+
+type recordingAuthMethod struct {
+	params map[string]string
+}
+
+func (m *recordingAuthMethod) Login(ctx context.Context, client *api.Client) error {
+	return nil
+}
+
+func (m *recordingAuthMethod) Logout(ctx context.Context, client *api.Client) error {
+	return nil
+}
+
+func TestParseAuthMethod(t *testing.T) {
+	RegisterAuthMethod("test-parse", func(p map[string]string) (AuthMethod, error) {
+		return &recordingAuthMethod{params: p}, nil
+	})
+
+	auth, err := ParseAuthMethod("test-parse://?role=my-role&mount=custom")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rec, ok := auth.(*recordingAuthMethod)
+	if !ok {
+		t.Fatalf("expected *recordingAuthMethod, got %T", auth)
+	}
+
+	if rec.params["role"] != "my-role" {
+		t.Errorf("expected role %q, got %q", "my-role", rec.params["role"])
+	}
+
+	if rec.params["mount"] != "custom" {
+		t.Errorf("expected mount %q, got %q", "custom", rec.params["mount"])
+	}
+}
+
+func TestParseAuthMethodUnregisteredScheme(t *testing.T) {
+	if _, err := ParseAuthMethod("nonexistent-scheme://"); err == nil {
+		t.Fatal("expected an error for an unregistered scheme")
+	}
+}
+
+func TestChosenEnvAuthMethod(t *testing.T) {
+	RegisterAuthMethod("test-chosen", func(p map[string]string) (AuthMethod, error) {
+		return &recordingAuthMethod{params: p}, nil
+	})
+
+	t.Setenv("VAULT_AUTH_METHOD", "test-chosen")
+	t.Setenv("VAULT_AUTH_MOUNT_PATH", "custom-mount")
+
+	auth := chosenEnvAuthMethod(os.DirFS("/"))
+
+	rec, ok := auth.(*recordingAuthMethod)
+	if !ok {
+		t.Fatalf("expected *recordingAuthMethod, got %T", auth)
+	}
+
+	if rec.params["mount"] != "custom-mount" {
+		t.Errorf("expected mount %q, got %q", "custom-mount", rec.params["mount"])
+	}
+}
+
+func TestChosenEnvAuthMethodUnset(t *testing.T) {
+	t.Setenv("VAULT_AUTH_METHOD", "")
+
+	if auth := chosenEnvAuthMethod(os.DirFS("/")); auth != nil {
+		t.Fatalf("expected a nil AuthMethod, got %T", auth)
+	}
+}
+
+func TestChosenEnvAuthMethodUnregisteredNameSurfacesError(t *testing.T) {
+	t.Setenv("VAULT_AUTH_METHOD", "not-a-registered-method")
+
+	auth := chosenEnvAuthMethod(os.DirFS("/"))
+	if auth == nil {
+		t.Fatal("expected a non-nil AuthMethod that surfaces the misconfiguration")
+	}
+
+	if err := auth.Login(context.Background(), &api.Client{}); err == nil {
+		t.Fatal("expected Login to fail with a descriptive error")
+	}
+}
@@ -0,0 +1,218 @@
+package vaultfs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// WithTokenRenewal wraps auth so that, when enabled, every successful Login
+// starts a tokenRenewer that keeps the acquired token alive in the
+// background, falling back to a full re-Login via auth when the token can
+// no longer be renewed. Any renewer from a previous Login is stopped after
+// the new one has been started.
+//
+// The renewer's background goroutine is independent of the context passed
+// to Login - that context scopes only the Login call's own network
+// round-trip, just like every other AuthMethod in this package, and a
+// caller using a short per-call timeout around Login must not have that
+// timeout cut off background renewal. The renewer instead runs off of its
+// own context, cancelled only by Stop (via Logout or a subsequent Login).
+//
+// Logout stops the renewer before revoking the token via auth.
+//
+// If enabled is false, auth is returned unchanged.
+func WithTokenRenewal(enabled bool, auth AuthMethod) AuthMethod {
+	if !enabled {
+		return auth
+	}
+
+	return &renewingAuthMethod{auth: auth, renewCtx: context.Background()}
+}
+
+type renewingAuthMethod struct {
+	auth AuthMethod
+
+	// renewCtx is the long-lived context background renewal goroutines run
+	// off of, independent of any particular Login call's context.
+	renewCtx context.Context
+
+	mu      sync.Mutex
+	renewer *tokenRenewer
+}
+
+func (m *renewingAuthMethod) Login(ctx context.Context, client *api.Client) error {
+	if err := m.auth.Login(ctx, client); err != nil {
+		return err
+	}
+
+	// A failure here is a renewal-bookkeeping problem, not a login failure -
+	// client already holds a valid token from the Login above, so it must
+	// not be reported as one. Treat it the same as a token that just isn't
+	// renewable: no renewer is started for this token, but Login succeeds.
+	renewer, _ := startTokenRenewer(ctx, m.renewCtx, client, m.auth)
+
+	m.mu.Lock()
+	old := m.renewer
+	m.renewer = renewer
+	m.mu.Unlock()
+
+	old.Stop()
+
+	return nil
+}
+
+func (m *renewingAuthMethod) Logout(ctx context.Context, client *api.Client) error {
+	m.mu.Lock()
+	renewer := m.renewer
+	m.renewer = nil
+	m.mu.Unlock()
+
+	renewer.Stop()
+
+	return m.auth.Logout(ctx, client)
+}
+
+// tokenRenewer keeps a Vault token alive in the background, using
+// client.NewLifetimeWatcher to renew it at roughly 2/3 of its TTL, and
+// falling back to a full re-Login through auth when the token can no longer
+// be renewed (its max TTL is reached, it's revoked, or the watcher errors).
+//
+// A tokenRenewer is owned by the renewingAuthMethod that starts it, and must
+// be stopped when that method logs out or re-logs in.
+type tokenRenewer struct {
+	auth   AuthMethod
+	client *api.Client
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// startTokenRenewer begins watching client's current token in the
+// background. checkCtx scopes only the initial token lookup; runCtx is the
+// long-lived context the background goroutine runs off of, independent of
+// checkCtx. It returns a nil renewer if the token isn't renewable.
+//
+// client.Auth().Token().LookupSelfWithContext returns a *api.Secret whose
+// Auth field is unpopulated (it's a token read, not a login response) - only
+// its Data map is, which is what TokenIsRenewable/TokenTTL parse. A fresh
+// *api.Secret with Auth populated is built from that before being handed to
+// NewLifetimeWatcher, which expects Auth to be set.
+func startTokenRenewer(checkCtx, runCtx context.Context, client *api.Client, auth AuthMethod) (*tokenRenewer, error) {
+	secret, err := renewalSecret(checkCtx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	if secret == nil {
+		return nil, nil
+	}
+
+	runCtx, cancel := context.WithCancel(runCtx)
+
+	r := &tokenRenewer{
+		auth:   auth,
+		client: client,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	go r.run(runCtx, secret)
+
+	return r, nil
+}
+
+// renewalSecret looks up client's current token and, if it's renewable,
+// returns an *api.Secret with Auth populated as NewLifetimeWatcher expects.
+// It returns a nil secret if the token isn't renewable.
+func renewalSecret(ctx context.Context, client *api.Client) (*api.Secret, error) {
+	lookup, err := client.Auth().Token().LookupSelfWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("token lookup failed: %w", err)
+	}
+
+	if renewable, _ := lookup.TokenIsRenewable(); !renewable {
+		return nil, nil
+	}
+
+	ttl, err := lookup.TokenTTL()
+	if err != nil || ttl <= 0 {
+		return nil, nil
+	}
+
+	return &api.Secret{
+		Auth: &api.SecretAuth{
+			ClientToken:   client.Token(),
+			Renewable:     true,
+			LeaseDuration: int(ttl.Seconds()),
+		},
+	}, nil
+}
+
+// Stop cancels the background renewal goroutine and waits for it to exit.
+// It's safe to call Stop on a nil renewer.
+func (r *tokenRenewer) Stop() {
+	if r == nil {
+		return
+	}
+
+	r.cancel()
+	<-r.done
+}
+
+func (r *tokenRenewer) run(ctx context.Context, secret *api.Secret) {
+	defer close(r.done)
+
+	for {
+		watcher, err := r.client.NewLifetimeWatcher(&api.LifetimeWatcherInput{
+			Secret:    secret,
+			Increment: secret.Auth.LeaseDuration * 2 / 3,
+		})
+		if err != nil {
+			return
+		}
+
+		go watcher.Start()
+
+		renewed := r.watch(ctx, watcher)
+
+		watcher.Stop()
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		if renewed != nil {
+			secret = renewed
+
+			continue
+		}
+
+		// the token can no longer be renewed - fall back to a full re-login
+		if err := r.auth.Login(ctx, r.client); err != nil {
+			return
+		}
+
+		secret, err = renewalSecret(ctx, r.client)
+		if err != nil || secret == nil {
+			return
+		}
+	}
+}
+
+// watch blocks until the watcher either successfully renews the token (the
+// returned secret is non-nil), errors out, or ctx is cancelled.
+func (r *tokenRenewer) watch(ctx context.Context, watcher *api.LifetimeWatcher) *api.Secret {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-watcher.DoneCh():
+			return nil
+		case renewal := <-watcher.RenewCh():
+			return renewal.Secret
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package vaultfs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// authMethodFunc adapts a function to an AuthMethod for testing. It's used
+// via a pointer so instances remain comparable (a plain func value isn't).
+type authMethodFunc struct {
+	login func(ctx context.Context, client *api.Client) error
+}
+
+func (f *authMethodFunc) Login(ctx context.Context, client *api.Client) error {
+	return f.login(ctx, client)
+}
+
+func (f *authMethodFunc) Logout(ctx context.Context, client *api.Client) error {
+	return nil
+}
+
+// lookupSelfHandler fakes Vault's auth/token/lookup-self response. It's used
+// for every request regardless of path, which is enough to exercise the
+// renewal loop without needing a full fake Vault server.
+func lookupSelfHandler(renewable bool, ttlSeconds int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"renewable": renewable,
+				"ttl":       ttlSeconds,
+			},
+		})
+	}
+}
+
+func testVaultClient(t *testing.T, handler http.HandlerFunc) *api.Client {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	cfg := api.DefaultConfig()
+	cfg.Address = srv.URL
+
+	client, err := api.NewClient(cfg)
+	if err != nil {
+		t.Fatalf("failed to create vault client: %v", err)
+	}
+
+	client.SetToken("test-token")
+
+	return client
+}
+
+func TestRenewalSecret(t *testing.T) {
+	t.Run("renewable", func(t *testing.T) {
+		client := testVaultClient(t, lookupSelfHandler(true, 3600))
+
+		secret, err := renewalSecret(context.Background(), client)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if secret == nil {
+			t.Fatal("expected a non-nil secret for a renewable token")
+		}
+
+		if !secret.Auth.Renewable {
+			t.Error("expected secret.Auth.Renewable to be true")
+		}
+
+		if secret.Auth.LeaseDuration != 3600 {
+			t.Errorf("expected lease duration 3600, got %d", secret.Auth.LeaseDuration)
+		}
+
+		if secret.Auth.ClientToken != client.Token() {
+			t.Errorf("expected client token %q, got %q", client.Token(), secret.Auth.ClientToken)
+		}
+	})
+
+	t.Run("not renewable", func(t *testing.T) {
+		client := testVaultClient(t, lookupSelfHandler(false, 3600))
+
+		secret, err := renewalSecret(context.Background(), client)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if secret != nil {
+			t.Fatal("expected a nil secret for a non-renewable token")
+		}
+	})
+
+	t.Run("zero ttl", func(t *testing.T) {
+		client := testVaultClient(t, lookupSelfHandler(true, 0))
+
+		secret, err := renewalSecret(context.Background(), client)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if secret != nil {
+			t.Fatal("expected a nil secret for a zero ttl")
+		}
+	})
+}
+
+func TestWithTokenRenewalDisabled(t *testing.T) {
+	fake := &authMethodFunc{login: func(ctx context.Context, client *api.Client) error { return nil }}
+
+	if got := WithTokenRenewal(false, fake); got != AuthMethod(fake) {
+		t.Fatalf("expected WithTokenRenewal(false, ...) to return auth unchanged, got %#v", got)
+	}
+}
+
+// TestRenewingAuthMethodOutlivesLoginContext verifies that cancelling the
+// context passed to Login doesn't stop the background renewer - only Stop
+// (via Logout or a subsequent Login) should.
+func TestRenewingAuthMethodOutlivesLoginContext(t *testing.T) {
+	var loginCalls int32
+
+	fake := &authMethodFunc{login: func(ctx context.Context, client *api.Client) error {
+		atomic.AddInt32(&loginCalls, 1)
+		client.SetToken("test-token")
+
+		return nil
+	}}
+
+	client := testVaultClient(t, lookupSelfHandler(true, 3600))
+
+	auth := WithTokenRenewal(true, fake)
+
+	loginCtx, cancel := context.WithCancel(context.Background())
+
+	if err := auth.Login(loginCtx, client); err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+
+	cancel()
+
+	rm, ok := auth.(*renewingAuthMethod)
+	if !ok {
+		t.Fatalf("expected *renewingAuthMethod, got %T", auth)
+	}
+
+	rm.mu.Lock()
+	renewer := rm.renewer
+	rm.mu.Unlock()
+
+	if renewer == nil {
+		t.Fatal("expected a renewer to have been started for a renewable token")
+	}
+
+	select {
+	case <-renewer.done:
+		t.Fatal("renewer exited after its Login call's context was cancelled")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if err := auth.Logout(context.Background(), client); err != nil {
+		t.Fatalf("logout failed: %v", err)
+	}
+
+	select {
+	case <-renewer.done:
+	case <-time.After(time.Second):
+		t.Fatal("renewer did not stop after Logout")
+	}
+}
+
+// failingLookupSelfHandler fakes a Vault server whose auth/token/lookup-self
+// endpoint errors, simulating a transient failure during renewer setup.
+func failingLookupSelfHandler(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusInternalServerError)
+}
+
+// TestRenewingAuthMethodSurvivesRenewerSetupFailure verifies that a failure
+// starting the token renewer doesn't fail Login - the wrapped auth method
+// already succeeded and client already holds a valid token - and that a
+// renewer from a previous Login is still stopped.
+func TestRenewingAuthMethodSurvivesRenewerSetupFailure(t *testing.T) {
+	fake := &authMethodFunc{login: func(ctx context.Context, client *api.Client) error {
+		client.SetToken("test-token")
+
+		return nil
+	}}
+
+	auth := WithTokenRenewal(true, fake)
+
+	goodClient := testVaultClient(t, lookupSelfHandler(true, 3600))
+
+	if err := auth.Login(context.Background(), goodClient); err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+
+	rm, ok := auth.(*renewingAuthMethod)
+	if !ok {
+		t.Fatalf("expected *renewingAuthMethod, got %T", auth)
+	}
+
+	rm.mu.Lock()
+	oldRenewer := rm.renewer
+	rm.mu.Unlock()
+
+	if oldRenewer == nil {
+		t.Fatal("expected a renewer to have been started for a renewable token")
+	}
+
+	failingClient := testVaultClient(t, failingLookupSelfHandler)
+
+	if err := auth.Login(context.Background(), failingClient); err != nil {
+		t.Fatalf("expected login to succeed despite renewer setup failure, got: %v", err)
+	}
+
+	select {
+	case <-oldRenewer.done:
+	case <-time.After(time.Second):
+		t.Fatal("previous renewer was not stopped when renewer setup failed")
+	}
+
+	rm.mu.Lock()
+	newRenewer := rm.renewer
+	rm.mu.Unlock()
+
+	if newRenewer != nil {
+		t.Fatal("expected no renewer to be recorded after a renewer setup failure")
+	}
+}